@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gorilla/mux"
+)
+
+// LambdaFunc is the Lambda entry-point signature shared by every route.
+type LambdaFunc func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// LambdaHandler binds an HTTP method and a gorilla/mux style path
+// (e.g. "/user/{id}") to a LambdaFunc, so several Lambdas can be
+// registered behind one mux.Router and exercised the same way whether
+// they run on AWS Lambda or behind a local HTTP server. PathParams
+// documents the path's `{var}` placeholders; it isn't consulted by
+// NewHTTPHandler, which resolves them at request time from the matched
+// mux route via mux.Vars instead, but it lets a route's parameters be
+// read off the LambdaHandler value itself.
+type LambdaHandler struct {
+	Method     string
+	Path       string
+	PathParams []string
+	Handler    LambdaFunc
+}
+
+// NewHTTPHandler builds a real net/http server that fronts the given
+// LambdaHandlers. Every incoming *http.Request is translated into an
+// events.APIGatewayProxyRequest (path parameters populated from the
+// matched mux route into PathParameters), and the
+// events.APIGatewayProxyResponse the handler returns is translated back
+// into a real HTTP response. This lets pact provider verification,
+// curl, or any other black-box HTTP client exercise the exact same
+// Lambda code that runs behind API Gateway, instead of the separate
+// mux-only code path the local dev server used to run. localHandlers
+// points every entry's Handler at APIGatewayLambdaHandler, which
+// dispatches through the single newAppRouter shared with Lambda mode, so
+// there's exactly one route table and one response-writing code path.
+func NewHTTPHandler(handlers []LambdaHandler, port int) *http.Server {
+	r := mux.NewRouter().StrictSlash(true)
+	for _, h := range handlers {
+		h := h
+		r.HandleFunc(h.Path, func(w http.ResponseWriter, r *http.Request) {
+			req, err := toAPIGatewayRequest(r, mux.Vars(r))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			resp, err := h.Handler(r.Context(), req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			writeAPIGatewayResponse(w, resp)
+		}).Methods(h.Method)
+	}
+
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: r,
+	}
+}
+
+// toAPIGatewayRequest converts a real HTTP request into the shape Lambda
+// would have received from API Gateway, including the path parameters
+// mux extracted from the matched route template. Both Headers and
+// MultiValueHeaders are populated (not just the single-value map), since
+// dispatch forwards MultiValueHeaders onward and a request with a
+// repeated header would otherwise silently lose every value but the
+// first.
+func toAPIGatewayRequest(r *http.Request, pathParams map[string]string) (events.APIGatewayProxyRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	headers := map[string]string{}
+	multiValueHeaders := map[string][]string{}
+	for k, v := range r.Header {
+		headers[k] = r.Header.Get(k)
+		multiValueHeaders[k] = v
+	}
+
+	query := map[string]string{}
+	for k := range r.URL.Query() {
+		query[k] = r.URL.Query().Get(k)
+	}
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod:            r.Method,
+		Path:                  r.URL.Path,
+		Headers:               headers,
+		MultiValueHeaders:     multiValueHeaders,
+		QueryStringParameters: query,
+		PathParameters:        pathParams,
+		Body:                  string(body),
+	}, nil
+}
+
+// writeAPIGatewayResponse writes a Lambda response onto a real
+// http.ResponseWriter, decoding the body first if API Gateway would have
+// base64-encoded it.
+func writeAPIGatewayResponse(w http.ResponseWriter, resp events.APIGatewayProxyResponse) {
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	for k, values := range resp.MultiValueHeaders {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		if decoded, err := base64.StdEncoding.DecodeString(resp.Body); err == nil {
+			body = decoded
+		}
+	}
+	w.Write(body)
+}
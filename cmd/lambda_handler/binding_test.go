@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBind_DecodesAndInvokesHandler(t *testing.T) {
+	handler := Bind(createUser, createUserSchema)
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp CreateUserResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Message != "User created" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+// noTagsRequest deliberately has no `validate:"required"` tags, so the
+// only thing that can catch a missing field is the disk-loaded JSON
+// schema's Required list.
+type noTagsRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func TestValidateRequest_SchemaCatchesFieldAbsentFromRawBody(t *testing.T) {
+	schema := &ValidationSchema{Required: []string{"name", "email"}}
+
+	rawBody := []byte(`{"name":"Alice"}`)
+	req := noTagsRequest{Name: "Alice"}
+
+	errs := validateRequest(req, rawBody, schema)
+	if len(errs) == 0 {
+		t.Fatal("expected schema validation to catch email missing from the raw body")
+	}
+}
+
+func TestValidateRequest_SchemaPassesWhenAllRequiredFieldsPresent(t *testing.T) {
+	schema := &ValidationSchema{Required: []string{"name", "email"}}
+
+	rawBody := []byte(`{"name":"Alice","email":"alice@example.com"}`)
+	req := noTagsRequest{Name: "Alice", Email: "alice@example.com"}
+
+	if errs := validateRequest(req, rawBody, schema); len(errs) != 0 {
+		t.Fatalf("expected no validation errors, got %v", errs)
+	}
+}
+
+func TestValidateRequest_DoesNotDuplicateErrorsWhenTagAndSchemaOverlap(t *testing.T) {
+	errs := validateRequest(CreateUserRequest{}, []byte(`{}`), createUserSchema)
+	if len(errs) != 2 {
+		t.Fatalf("expected exactly one error per missing field, got %v", errs)
+	}
+}
+
+func TestBind_RejectsMissingRequiredFieldAsProblemJSON(t *testing.T) {
+	handler := Bind(createUser, createUserSchema)
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Alice"})
+	req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected problem+json content type, got %q", ct)
+	}
+}
@@ -3,63 +3,75 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/gorilla/mux"
 )
 
-// APIGatewayLambdaHandler - API Gateway からのリクエストを処理
-func APIGatewayLambdaHandler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	// 🔥 API Gateway のリクエスト詳細をログに出力
-	logRequestDetails(req)
-
-	// Gorilla Mux ルーターを作成
-	r := mux.NewRouter().StrictSlash(true)
-
-	// ルートハンドラーを登録 (エンドポイントごとに処理を分ける)
-	r.HandleFunc("/", rootHandler).Methods("GET")
-	r.HandleFunc("/test", testHandler).Methods("GET")
-	r.HandleFunc("/user", userHandler).Methods("GET", "POST")
-	r.HandleFunc("/order", orderHandler).Methods("GET", "POST")
-
-	// `req.Path` からパスを取得
-	reqPath := normalizePath(req.Path)
-	httpMethod := req.HTTPMethod
-
-	// リクエストを Mux で処理
-	body := ioutil.NopCloser(strings.NewReader(req.Body))
-	httpReq, err := http.NewRequest(httpMethod, reqPath, body)
+// apiGatewayCore runs the shared app router for an API Gateway request.
+// Logging, panic recovery, and CORS are layered on top of it below as
+// middleware rather than being inlined here.
+func apiGatewayCore(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	result, err := dispatch(fromAPIGatewayProxyRequest(req))
 	if err != nil {
-		log.Println("Error creating request:", err)
 		return events.APIGatewayProxyResponse{StatusCode: 500, Body: "Internal Server Error"}, nil
 	}
 
-	// ヘッダーをコピー
-	for k, v := range req.Headers {
-		httpReq.Header.Set(k, v)
-	}
-
-	// Mux に渡すカスタムレスポンスライター
-	rw := &ResponseWriter{Headers: map[string]string{}, StatusCode: 404}
-	r.ServeHTTP(rw, httpReq)
+	return events.APIGatewayProxyResponse{
+		StatusCode:        result.StatusCode,
+		Headers:           result.Headers,
+		MultiValueHeaders: result.MultiValueHeaders,
+		Body:              result.Body,
+		IsBase64Encoded:   result.IsBase64Encoded,
+	}, nil
+}
 
-	// Lambda のレスポンスを構成
-	response := events.APIGatewayProxyResponse{
-		StatusCode: rw.StatusCode,
-		Headers:    rw.Headers,
-		Body:       rw.Body,
+// APIGatewayLambdaHandler - API Gateway (REST / HTTP API) からのリクエストを処理。
+// panic recovery → structured logging → CORS → gzip compression の順にミドルウェアを適用する。
+var APIGatewayLambdaHandler LambdaFunc = Chain(apiGatewayCore, WithRecover, WithStructuredLogging, WithCORS([]string{"*"}), WithGzip)
+
+// ALBTargetGroupHandler - ALB ターゲットグループからのリクエストを処理。
+// ALB イベントを合成の APIGatewayProxyRequest に変換して
+// APIGatewayLambdaHandler (panic recovery → structured logging → CORS →
+// gzip のミドルウェアチェーン込み) にそのまま委譲し、応答を
+// ALBTargetGroupResponse に変換し直すことで、2 つの統合方式でミドルウェアが
+// 乖離しないようにする。
+func ALBTargetGroupHandler(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	resp, err := APIGatewayLambdaHandler(ctx, toAPIGatewayProxyRequestFromALB(req))
+	if err != nil {
+		return events.ALBTargetGroupResponse{StatusCode: 500, Body: "Internal Server Error"}, nil
 	}
+	return toALBTargetGroupResponse(resp), nil
+}
 
-	// 🔥 レスポンスの詳細をログに出力
-	log.Printf("Response: %+v\n", response)
+// APIGatewayV2Handler - API Gateway HTTP API (payload format 2.0) からの
+// リクエストを処理。ALBTargetGroupHandler と同じ方針で、合成の
+// APIGatewayProxyRequest に変換して APIGatewayLambdaHandler に委譲し、
+// 応答を APIGatewayV2HTTPResponse に変換し直す。
+func APIGatewayV2Handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	resp, err := APIGatewayLambdaHandler(ctx, toAPIGatewayProxyRequestFromV2(req))
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{StatusCode: 500, Body: "Internal Server Error"}, nil
+	}
+	return toAPIGatewayV2HTTPResponse(resp), nil
+}
 
-	return response, nil
+// localHandlers - ローカルサーバー / pact 検証の両方から使う LambdaHandler 一覧。
+// 各エントリーの Handler は APIGatewayLambdaHandler そのもの: Lambda 上で
+// API Gateway のリクエストを処理するのと同じ newAppRouter 経由の dispatch と
+// 同じミドルウェアチェーン (panic recovery → structured logging → CORS →
+// gzip) を通すことで、ローカル経路だけ CORS が抜けるといった乖離が起きない
+// ようにする。ルートはすべて appRoutes (router.go) の一箇所だけで登録される。
+func localHandlers() []LambdaHandler {
+	routes := appRoutes()
+	handlers := make([]LambdaHandler, 0, len(routes))
+	for _, rt := range routes {
+		handlers = append(handlers, LambdaHandler{Method: rt.Method, Path: rt.Path, Handler: APIGatewayLambdaHandler})
+	}
+	return handlers
 }
 
 // 🔹 ルート ("/") のハンドラー
@@ -76,92 +88,67 @@ func testHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// 🔹 `/user` のハンドラー (GET & POST)
-func userHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		response := map[string]string{"message": "User created"}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	} else {
-		response := map[string]string{"message": "User endpoint"}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}
+// 🔹 `/user` の GET ハンドラー
+func getUserHandler(w http.ResponseWriter, r *http.Request) {
+	response := map[string]string{"message": "User endpoint"}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-// 🔹 `/order` のハンドラー (GET & POST)
-func orderHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		response := map[string]string{"message": "Order created"}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	} else {
-		response := map[string]string{"message": "Order endpoint"}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}
+// CreateUserRequest - POST /user のリクエストボディ
+type CreateUserRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required"`
 }
 
-// 🔥 API Gateway のリクエスト詳細をログに出力
-func logRequestDetails(req events.APIGatewayProxyRequest) {
-	logData := map[string]interface{}{
-		"HTTPMethod":  req.HTTPMethod,
-		"Path":        req.Path,
-		"Headers":     req.Headers,
-		"QueryParams": req.QueryStringParameters,
-		"PathParams":  req.PathParameters,
-		"RequestID":   req.RequestContext.RequestID,
-		"Stage":       req.RequestContext.Stage,
-		"Domain":      req.RequestContext.DomainName,
-		"Body":        req.Body,
-	}
-	jsonData, err := json.MarshalIndent(logData, "", "  ")
-	if err != nil {
-		log.Println("Error marshaling request data:", err)
-	} else {
-		log.Println("🔥 Received API Gateway Event:\n", string(jsonData))
-	}
+// CreateUserResponse - POST /user のレスポンスボディ
+type CreateUserResponse struct {
+	Message string `json:"message"`
 }
 
-func normalizePath(path string) string {
-	parts := strings.Split(path, "/")
-	if len(parts) > 2 {
-		return "/" + strings.Join(parts[2:], "/")
-	}
-	return path
+// createUserSchema - schemas/create_user.json から起動時に読み込む JSON スキーマ
+var createUserSchema = mustLoadSchema("schemas/create_user.json")
+
+// createUser - POST /user のビジネスロジック。Bind 経由でデコード・検証済みの
+// CreateUserRequest を受け取る。
+func createUser(ctx context.Context, req CreateUserRequest) (CreateUserResponse, error) {
+	return CreateUserResponse{Message: "User created"}, nil
 }
 
-// ResponseWriter カスタムレスポンスライター
-type ResponseWriter struct {
-	StatusCode int
-	Headers    map[string]string
-	Body       string
+// 🔹 `/order` の GET ハンドラー
+func getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	response := map[string]string{"message": "Order endpoint"}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-func (rw *ResponseWriter) Header() http.Header {
-	return http.Header{}
+// CreateOrderRequest - POST /order のリクエストボディ
+type CreateOrderRequest struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required"`
 }
 
-func (rw *ResponseWriter) Write(b []byte) (int, error) {
-	rw.Body = string(b)
-	return len(b), nil
+// CreateOrderResponse - POST /order のレスポンスボディ
+type CreateOrderResponse struct {
+	Message string `json:"message"`
 }
 
-func (rw *ResponseWriter) WriteHeader(statusCode int) {
-	rw.StatusCode = statusCode
+// createOrderSchema - schemas/create_order.json から起動時に読み込む JSON スキーマ
+var createOrderSchema = mustLoadSchema("schemas/create_order.json")
+
+// createOrder - POST /order のビジネスロジック。Bind 経由でデコード・検証済みの
+// CreateOrderRequest を受け取る。
+func createOrder(ctx context.Context, req CreateOrderRequest) (CreateOrderResponse, error) {
+	return CreateOrderResponse{Message: "Order created"}, nil
 }
 
 func main() {
 	if _, isLambda := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); isLambda {
-		lambda.Start(APIGatewayLambdaHandler)
+		// イベントの形で API Gateway / ALB どちらからの呼び出しか判定する
+		lambda.Start(rawEventHandler)
 	} else {
-		r := mux.NewRouter().StrictSlash(true)
-		r.HandleFunc("/", rootHandler).Methods("GET")
-		r.HandleFunc("/test", testHandler).Methods("GET")
-		r.HandleFunc("/user", userHandler).Methods("GET", "POST")
-		r.HandleFunc("/order", orderHandler).Methods("GET", "POST")
-
+		srv := NewHTTPHandler(localHandlers(), 8080)
 		log.Println("Starting local server on :8080")
-		log.Fatal(http.ListenAndServe(":8080", r))
+		log.Fatal(srv.ListenAndServe())
 	}
 }
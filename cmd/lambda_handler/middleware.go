@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Middleware wraps a LambdaFunc with cross-cutting behavior (logging,
+// panic recovery, CORS, compression, ...), the same way http middleware
+// wraps an http.Handler.
+type Middleware func(LambdaFunc) LambdaFunc
+
+// Chain applies middlewares to handler in the order given: the first
+// middleware runs first on the way in and last on the way out.
+func Chain(handler LambdaFunc, middlewares ...Middleware) LambdaFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// structuredLogger replaces the old log.Printf("🔥 ...") calls with one
+// structured JSON line per request.
+var structuredLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// WithStructuredLogging emits one structured log line per request with
+// method, path, status, latency, and the API Gateway
+// RequestID/Stage/DomainName.
+func WithStructuredLogging(next LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		structuredLogger.Info("request",
+			"method", req.HTTPMethod,
+			"path", req.Path,
+			"status", resp.StatusCode,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"request_id", req.RequestContext.RequestID,
+			"stage", req.RequestContext.Stage,
+			"domain", req.RequestContext.DomainName,
+		)
+		return resp, err
+	}
+}
+
+// WithRecover converts a panic inside next into a 500 response instead of
+// crashing the Lambda invocation.
+func WithRecover(next LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (resp events.APIGatewayProxyResponse, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				structuredLogger.Error("panic recovered", "panic", fmt.Sprint(r), "path", req.Path)
+				resp = events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError, Body: "Internal Server Error"}
+				err = nil
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// WithCORS sets Access-Control-Allow-Origin for origins in allowedOrigins
+// ("*" allows every origin).
+func WithCORS(allowedOrigins []string) Middleware {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		allowed[o] = true
+	}
+
+	return func(next LambdaFunc) LambdaFunc {
+		return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			resp, err := next(ctx, req)
+			if err != nil {
+				return resp, err
+			}
+
+			origin := req.Headers["Origin"]
+			if !allowed["*"] && !allowed[origin] {
+				return resp, err
+			}
+
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+			if allowed["*"] {
+				resp.Headers["Access-Control-Allow-Origin"] = "*"
+			} else {
+				resp.Headers["Access-Control-Allow-Origin"] = origin
+			}
+			return resp, err
+		}
+	}
+}
+
+// WithGzip compresses the response body when the client sent
+// Accept-Encoding: gzip.
+func WithGzip(next LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil || resp.IsBase64Encoded || !strings.Contains(req.Headers["Accept-Encoding"], "gzip") {
+			return resp, err
+		}
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, werr := gz.Write([]byte(resp.Body)); werr != nil {
+			return resp, err
+		}
+		if cerr := gz.Close(); cerr != nil {
+			return resp, err
+		}
+
+		if resp.Headers == nil {
+			resp.Headers = map[string]string{}
+		}
+		resp.Headers["Content-Encoding"] = "gzip"
+		resp.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+		resp.IsBase64Encoded = true
+		return resp, err
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestWithRecover_ConvertsPanicToInternalServerError(t *testing.T) {
+	panicky := func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		panic("boom")
+	}
+
+	resp, err := WithRecover(panicky)(context.Background(), events.APIGatewayProxyRequest{})
+	if err != nil {
+		t.Fatalf("expected recovered panic to not surface as an error, got %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Fatalf("expected status 500 after recovering from panic, got %d", resp.StatusCode)
+	}
+}
+
+func TestWithCORS_SetsAllowOriginForWildcard(t *testing.T) {
+	ok := func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	}
+
+	resp, err := WithCORS([]string{"*"})(ok)(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Origin": "https://example.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Headers["Access-Control-Allow-Origin"] != "*" {
+		t.Fatalf("expected wildcard CORS header, got %+v", resp.Headers)
+	}
+}
+
+func TestWithGzip_CompressesBodyWhenAcceptEncodingRequestsIt(t *testing.T) {
+	const body = "hello, gzip"
+	ok := func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 200, Body: body}, nil
+	}
+
+	resp, err := WithGzip(ok)(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Accept-Encoding": "gzip, deflate"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.IsBase64Encoded {
+		t.Fatal("expected gzipped body to be marked base64-encoded")
+	}
+	if resp.Headers["Content-Encoding"] != "gzip" {
+		t.Fatalf("expected Content-Encoding: gzip, got %+v", resp.Headers)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to decode base64 body: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to open gzip reader: %v", err)
+	}
+	defer gz.Close()
+	decompressed, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress body: %v", err)
+	}
+	if string(decompressed) != body {
+		t.Fatalf("expected decompressed body %q, got %q", body, decompressed)
+	}
+}
+
+func TestWithGzip_LeavesBodyAloneWithoutAcceptEncoding(t *testing.T) {
+	const body = "hello, plain"
+	ok := func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 200, Body: body}, nil
+	}
+
+	resp, err := WithGzip(ok)(context.Background(), events.APIGatewayProxyRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.IsBase64Encoded || resp.Body != body {
+		t.Fatalf("expected body to pass through untouched, got %+v", resp)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// BasePath strips a custom-domain base-path mapping (e.g. "/v1") that API
+// Gateway doesn't report as the deployment stage. It's read once from
+// API_BASE_PATH at startup; tests override it directly.
+var BasePath = os.Getenv("API_BASE_PATH")
+
+// normalizePath strips the API Gateway deployment stage (from
+// req.RequestContext.Stage) and/or the configured custom-domain BasePath
+// from req.Path, preserving the rest of the URL verbatim. The previous
+// implementation blindly cut the first two path segments, which mangled
+// single-segment stages and any real path deeper than two segments (e.g.
+// "/users/123/orders"); this only strips a prefix that's actually there.
+func normalizePath(req events.APIGatewayProxyRequest) string {
+	path := req.Path
+
+	if BasePath != "" {
+		path = stripPathPrefix(path, BasePath)
+	}
+
+	if stage := req.RequestContext.Stage; stage != "" && stage != "$default" {
+		path = stripPathPrefix(path, stage)
+	}
+
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// stripPathPrefix removes a leading "/prefix" segment from path, if
+// path is exactly "/prefix" or starts with "/prefix/". Any other path
+// (non-stage-prefixed, or a different prefix entirely) is returned
+// unchanged.
+func stripPathPrefix(path, prefix string) string {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	switch {
+	case path == prefix:
+		return "/"
+	case strings.HasPrefix(path, prefix+"/"):
+		return path[len(prefix):]
+	default:
+		return path
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		stage    string
+		basePath string
+		want     string
+	}{
+		{name: "root with stage", path: "/dev", stage: "dev", want: "/"},
+		{name: "stage-prefixed", path: "/dev/users/123", stage: "dev", want: "/users/123"},
+		{name: "non-stage-prefixed path is left alone", path: "/users/123", stage: "dev", want: "/users/123"},
+		{name: "deeply nested path keeps every segment", path: "/dev/users/123/orders/456", stage: "dev", want: "/users/123/orders/456"},
+		{name: "no stage on the event", path: "/users/123", stage: "", want: "/users/123"},
+		{name: "$default HTTP API stage is not a prefix", path: "/users/123", stage: "$default", want: "/users/123"},
+		{name: "custom domain base path mapping", path: "/v1/users/123", stage: "", basePath: "v1", want: "/users/123"},
+		{name: "base path and stage both present", path: "/v1/dev/users/123", stage: "dev", basePath: "v1", want: "/users/123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BasePath = tt.basePath
+			defer func() { BasePath = "" }()
+
+			req := events.APIGatewayProxyRequest{Path: tt.path}
+			req.RequestContext.Stage = tt.stage
+
+			if got := normalizePath(req); got != tt.want {
+				t.Errorf("normalizePath(%q, stage=%q, basePath=%q) = %q, want %q", tt.path, tt.stage, tt.basePath, got, tt.want)
+			}
+		})
+	}
+}
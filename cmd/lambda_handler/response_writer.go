@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"unicode/utf8"
+)
+
+// ResponseWriter is a minimal http.ResponseWriter that captures a
+// response so it can be translated back into a Lambda response. Unlike a
+// bare struct, it behaves like a real net/http ResponseWriter: Header()
+// always returns the same http.Header, WriteHeader (or the first Write)
+// freezes that header, and repeated Write calls append to the body
+// instead of clobbering it.
+type ResponseWriter struct {
+	StatusCode int
+
+	header      http.Header
+	frozen      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// NewResponseWriter creates a ResponseWriter. defaultStatus is returned
+// verbatim if the handler run against it never calls Write or
+// WriteHeader (e.g. a matched handler that returns without writing
+// anything). An unmatched route doesn't hit this case: mux answers it
+// itself with 404 or 405.
+func NewResponseWriter(defaultStatus int) *ResponseWriter {
+	return &ResponseWriter{StatusCode: defaultStatus, header: http.Header{}}
+}
+
+func (rw *ResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.body.Write(b)
+}
+
+func (rw *ResponseWriter) WriteHeader(statusCode int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.StatusCode = statusCode
+	rw.wroteHeader = true
+	rw.frozen = rw.header.Clone()
+}
+
+// finalize produces the headers, body, and encoding Lambda would send to
+// API Gateway / ALB: it fills in Content-Type via http.DetectContentType
+// when the handler never set one, splits repeated header keys into
+// MultiValueHeaders, and base64-encodes the body when it isn't valid
+// UTF-8 text.
+func (rw *ResponseWriter) finalize() (headers map[string]string, multiValueHeaders map[string][]string, body string, isBase64Encoded bool) {
+	h := rw.frozen
+	if h == nil {
+		h = rw.header
+	}
+
+	bodyBytes := rw.body.Bytes()
+	if h.Get("Content-Type") == "" && len(bodyBytes) > 0 {
+		h.Set("Content-Type", http.DetectContentType(bodyBytes))
+	}
+
+	headers = map[string]string{}
+	multiValueHeaders = map[string][]string{}
+	for k, values := range h {
+		headers[k] = values[0]
+		if len(values) > 1 {
+			multiValueHeaders[k] = values
+		}
+	}
+
+	if utf8.Valid(bodyBytes) {
+		return headers, multiValueHeaders, string(bodyBytes), false
+	}
+	return headers, multiValueHeaders, base64.StdEncoding.EncodeToString(bodyBytes), true
+}
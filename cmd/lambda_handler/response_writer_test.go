@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestResponseWriter_AppendsBodyAcrossWrites(t *testing.T) {
+	rw := NewResponseWriter(404)
+	rw.Write([]byte("hello, "))
+	rw.Write([]byte("world"))
+
+	_, _, body, _ := rw.finalize()
+	if body != "hello, world" {
+		t.Fatalf("expected body to accumulate across writes, got %q", body)
+	}
+}
+
+func TestResponseWriter_HeadersPersistAcrossCalls(t *testing.T) {
+	rw := NewResponseWriter(404)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write([]byte(`{"ok":true}`))
+
+	headers, _, _, _ := rw.finalize()
+	if headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected Content-Type to persist, got headers %+v", headers)
+	}
+}
+
+func TestResponseWriter_DetectsContentTypeWhenUnset(t *testing.T) {
+	rw := NewResponseWriter(404)
+	rw.Write([]byte("plain text body"))
+
+	headers, _, _, _ := rw.finalize()
+	if headers["Content-Type"] == "" {
+		t.Fatalf("expected Content-Type to be auto-detected, got headers %+v", headers)
+	}
+}
+
+func TestResponseWriter_MultiValueHeaders(t *testing.T) {
+	rw := NewResponseWriter(404)
+	rw.Header().Add("Set-Cookie", "a=1")
+	rw.Header().Add("Set-Cookie", "b=2")
+	rw.Write([]byte("ok"))
+
+	_, multiValueHeaders, _, _ := rw.finalize()
+	if len(multiValueHeaders["Set-Cookie"]) != 2 {
+		t.Fatalf("expected 2 Set-Cookie values, got %v", multiValueHeaders["Set-Cookie"])
+	}
+}
+
+func TestAPIGatewayLambdaHandler_ContentTypeReachesResponse(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/test",
+	}
+
+	resp, err := APIGatewayLambdaHandler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected Content-Type set by testHandler to reach the response, got headers %+v", resp.Headers)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gorilla/mux"
+)
+
+// normalizedRequest is the common shape an API Gateway (REST / HTTP API
+// v2) event gets reduced to before it reaches `mux`, so the route
+// handlers registered in newAppRouter don't need to know which
+// integration fronted the Lambda. ALB target-group events don't go
+// through this directly: they're converted into a synthetic
+// APIGatewayProxyRequest first (see toAPIGatewayProxyRequestFromALB) and
+// share this path from there.
+type normalizedRequest struct {
+	HTTPMethod            string
+	Path                  string
+	Headers               map[string]string
+	MultiValueHeaders     map[string][]string
+	QueryStringParameters map[string]string
+	Body                  string
+}
+
+// fromAPIGatewayProxyRequest normalizes an API Gateway REST/HTTP API event.
+func fromAPIGatewayProxyRequest(req events.APIGatewayProxyRequest) normalizedRequest {
+	return normalizedRequest{
+		HTTPMethod:            req.HTTPMethod,
+		Path:                  normalizePath(req),
+		Headers:               req.Headers,
+		MultiValueHeaders:     req.MultiValueHeaders,
+		QueryStringParameters: req.QueryStringParameters,
+		Body:                  req.Body,
+	}
+}
+
+// toAPIGatewayProxyRequestFromALB converts an ALB target-group event into
+// the synthetic APIGatewayProxyRequest shape APIGatewayLambdaHandler
+// expects, so the ALB integration can run through the exact same
+// middleware chain (panic recovery, structured logging, CORS, gzip)
+// instead of duplicating it. ALB doesn't have the concept of a
+// deployment stage, so RequestContext.Stage is left empty and
+// normalizePath strips no stage from the path (only a configured
+// BasePath, if one is set).
+func toAPIGatewayProxyRequestFromALB(req events.ALBTargetGroupRequest) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod:            req.HTTPMethod,
+		Path:                  req.Path,
+		Headers:               req.Headers,
+		MultiValueHeaders:     req.MultiValueHeaders,
+		QueryStringParameters: req.QueryStringParameters,
+		Body:                  req.Body,
+		IsBase64Encoded:       req.IsBase64Encoded,
+	}
+}
+
+// toALBTargetGroupResponse converts the APIGatewayProxyResponse produced
+// by APIGatewayLambdaHandler back into the shape ALB expects.
+func toALBTargetGroupResponse(resp events.APIGatewayProxyResponse) events.ALBTargetGroupResponse {
+	return events.ALBTargetGroupResponse{
+		StatusCode:        resp.StatusCode,
+		StatusDescription: http.StatusText(resp.StatusCode),
+		Headers:           resp.Headers,
+		MultiValueHeaders: resp.MultiValueHeaders,
+		Body:              resp.Body,
+		IsBase64Encoded:   resp.IsBase64Encoded,
+	}
+}
+
+// toAPIGatewayProxyRequestFromV2 converts an HTTP API (payload format
+// 2.0) event into the synthetic APIGatewayProxyRequest shape
+// APIGatewayLambdaHandler expects, so HTTP API traffic runs through the
+// exact same router and middleware chain as REST API traffic instead of
+// a separate code path. V2 events carry the method/path under
+// RequestContext.HTTP and the raw path under RawPath rather than the
+// top-level HTTPMethod/Path fields payload format 1.0 uses.
+func toAPIGatewayProxyRequestFromV2(req events.APIGatewayV2HTTPRequest) events.APIGatewayProxyRequest {
+	out := events.APIGatewayProxyRequest{
+		HTTPMethod:            req.RequestContext.HTTP.Method,
+		Path:                  req.RawPath,
+		Headers:               req.Headers,
+		QueryStringParameters: req.QueryStringParameters,
+		PathParameters:        req.PathParameters,
+		Body:                  req.Body,
+		IsBase64Encoded:       req.IsBase64Encoded,
+	}
+	out.RequestContext.Stage = req.RequestContext.Stage
+	return out
+}
+
+// toAPIGatewayV2HTTPResponse converts the APIGatewayProxyResponse
+// produced by APIGatewayLambdaHandler back into the shape HTTP API
+// expects.
+func toAPIGatewayV2HTTPResponse(resp events.APIGatewayProxyResponse) events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode:        resp.StatusCode,
+		Headers:           resp.Headers,
+		MultiValueHeaders: resp.MultiValueHeaders,
+		Body:              resp.Body,
+		IsBase64Encoded:   resp.IsBase64Encoded,
+	}
+}
+
+// appRoute pairs a method+path with its business handler and any
+// route-specific middleware to layer inside the global chain that
+// APIGatewayLambdaHandler/ALBTargetGroupHandler/APIGatewayV2Handler wrap
+// the whole router in. No route needs one of its own today, but the
+// field makes middleware attachable per-route via the mux.Router as
+// well as globally, instead of only at the LambdaFunc level.
+type appRoute struct {
+	Method      string
+	Path        string
+	Handler     http.HandlerFunc
+	Middlewares []Middleware
+}
+
+// appRoutes is the one route table every integration (Lambda-mode API
+// Gateway, Lambda-mode ALB, Lambda-mode HTTP API v2, and the local/pact
+// HTTP adapter) shares, via newAppRouter. Registering a route here once
+// is what keeps the integrations from drifting.
+func appRoutes() []appRoute {
+	return []appRoute{
+		{Method: "GET", Path: "/", Handler: rootHandler},
+		{Method: "GET", Path: "/test", Handler: testHandler},
+		{Method: "GET", Path: "/user", Handler: getUserHandler},
+		{Method: "POST", Path: "/user", Handler: Bind(createUser, createUserSchema)},
+		{Method: "GET", Path: "/order", Handler: getOrderHandler},
+		{Method: "POST", Path: "/order", Handler: Bind(createOrder, createOrderSchema)},
+	}
+}
+
+// newAppRouter builds the `mux.Router` shared by every integration from
+// appRoutes, so route registration can't drift between them.
+func newAppRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	for _, rt := range appRoutes() {
+		handler := rt.Handler
+		if len(rt.Middlewares) > 0 {
+			handler = withRouteMiddleware(rt.Method, rt.Path, handler, rt.Middlewares...)
+		}
+		r.HandleFunc(rt.Path, handler).Methods(rt.Method)
+	}
+	return r
+}
+
+// withRouteMiddleware lets a single appRoute opt into LambdaFunc-level
+// middleware (the same Middleware type the global chain around
+// APIGatewayLambdaHandler uses) without affecting any other route: it
+// replays the request through a private single-route mux.Router via
+// makeLambdaFunc, runs the middlewares, and writes the result back with
+// writeAPIGatewayResponse.
+func withRouteMiddleware(method, path string, handler http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	fn := Chain(makeLambdaFunc(method, path, handler), middlewares...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := toAPIGatewayRequest(r, mux.Vars(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeAPIGatewayResponse(w, resp)
+	}
+}
+
+// makeLambdaFunc adapts a plain net/http handler into a LambdaFunc by
+// replaying an API Gateway request against it through a private
+// single-route mux.Router and capturing the result with ResponseWriter.
+// withRouteMiddleware uses this to give an individual appRoute
+// LambdaFunc-level middleware without registering a second route table.
+func makeLambdaFunc(method, path string, handler http.HandlerFunc) LambdaFunc {
+	r := mux.NewRouter().StrictSlash(true)
+	r.HandleFunc(path, handler).Methods(method)
+
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return invokeRouter(r, req)
+	}
+}
+
+// invokeRouter replays an API Gateway request against a mux.Router and
+// captures the result as an API Gateway response.
+func invokeRouter(r *mux.Router, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	body := ioutil.NopCloser(strings.NewReader(req.Body))
+	httpReq, err := http.NewRequest(req.HTTPMethod, req.Path, body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 500, Body: "Internal Server Error"}, nil
+	}
+
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	rw := NewResponseWriter(http.StatusNotFound)
+	r.ServeHTTP(rw, httpReq)
+
+	headers, multiValueHeaders, respBody, isBase64Encoded := rw.finalize()
+	return events.APIGatewayProxyResponse{
+		StatusCode:        rw.StatusCode,
+		Headers:           headers,
+		MultiValueHeaders: multiValueHeaders,
+		Body:              respBody,
+		IsBase64Encoded:   isBase64Encoded,
+	}, nil
+}
+
+// dispatchResult is the integration-agnostic result of running a request
+// through the app router; apiGatewayCore translates it into an
+// events.APIGatewayProxyResponse, which the ALB and HTTP API v2 entry
+// points then convert into their own response types.
+type dispatchResult struct {
+	StatusCode        int
+	Headers           map[string]string
+	MultiValueHeaders map[string][]string
+	Body              string
+	IsBase64Encoded   bool
+}
+
+// dispatch runs a normalizedRequest through the shared app router and
+// captures the result with ResponseWriter, regardless of which
+// integration produced the request.
+func dispatch(req normalizedRequest) (dispatchResult, error) {
+	r := newAppRouter()
+
+	body := ioutil.NopCloser(strings.NewReader(req.Body))
+	httpReq, err := http.NewRequest(req.HTTPMethod, req.Path, body)
+	if err != nil {
+		return dispatchResult{}, err
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	for k, values := range req.MultiValueHeaders {
+		for _, v := range values {
+			httpReq.Header.Add(k, v)
+		}
+	}
+
+	rw := NewResponseWriter(http.StatusNotFound)
+	r.ServeHTTP(rw, httpReq)
+
+	headers, multiValueHeaders, respBody, isBase64Encoded := rw.finalize()
+	return dispatchResult{
+		StatusCode:        rw.StatusCode,
+		Headers:           headers,
+		MultiValueHeaders: multiValueHeaders,
+		Body:              respBody,
+		IsBase64Encoded:   isBase64Encoded,
+	}, nil
+}
+
+// rawEventShape is just enough of an incoming Lambda event to tell API
+// Gateway REST (payload format 1.0), HTTP API (payload format 2.0), and
+// ALB target-group invocations apart without committing to any one event
+// type up front.
+type rawEventShape struct {
+	Version        string `json:"version"`
+	RequestContext struct {
+		ELB *struct{} `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// rawEventHandler is the actual function registered with lambda.Start. It
+// sniffs the raw event to tell whether the Lambda was invoked by an ALB
+// target group, API Gateway HTTP API (payload format 2.0), or API
+// Gateway REST (payload format 1.0, the default when "version" is
+// absent), unmarshals into the matching typed event, and dispatches to
+// the matching handler above.
+func rawEventHandler(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var shape rawEventShape
+	if err := json.Unmarshal(raw, &shape); err != nil {
+		return nil, err
+	}
+
+	if shape.RequestContext.ELB != nil {
+		var req events.ALBTargetGroupRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, err
+		}
+		return ALBTargetGroupHandler(ctx, req)
+	}
+
+	if shape.Version == "2.0" {
+		var req events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, err
+		}
+		return APIGatewayV2Handler(ctx, req)
+	}
+
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, err
+	}
+	return APIGatewayLambdaHandler(ctx, req)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestToAPIGatewayProxyRequestFromV2(t *testing.T) {
+	v2 := events.APIGatewayV2HTTPRequest{
+		RawPath: "/users/123",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"name":"Alice"}`,
+	}
+	v2.RequestContext.HTTP.Method = "POST"
+	v2.RequestContext.Stage = "$default"
+
+	got := toAPIGatewayProxyRequestFromV2(v2)
+
+	if got.HTTPMethod != "POST" {
+		t.Errorf("HTTPMethod = %q, want POST", got.HTTPMethod)
+	}
+	if got.Path != "/users/123" {
+		t.Errorf("Path = %q, want /users/123", got.Path)
+	}
+	if got.Body != v2.Body {
+		t.Errorf("Body = %q, want %q", got.Body, v2.Body)
+	}
+	if got.RequestContext.Stage != "$default" {
+		t.Errorf("RequestContext.Stage = %q, want $default", got.RequestContext.Stage)
+	}
+}
+
+func TestWithRouteMiddleware_AppliesToThatRoute(t *testing.T) {
+	plain := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	tagged := func(next LambdaFunc) LambdaFunc {
+		return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			resp, err := next(ctx, req)
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+			resp.Headers["X-Route-Middleware"] = "applied"
+			return resp, err
+		}
+	}
+
+	handler := withRouteMiddleware("GET", "/tagged", plain, tagged)
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/tagged", nil))
+
+	if rec.Header().Get("X-Route-Middleware") != "applied" {
+		t.Fatalf("expected route-specific middleware to run, got headers %+v", rec.Header())
+	}
+}
+
+func TestRawEventHandler_RoutesHTTPAPIV2Event(t *testing.T) {
+	raw := []byte(`{
+		"version": "2.0",
+		"rawPath": "/test",
+		"requestContext": {"http": {"method": "GET"}, "stage": "$default"}
+	}`)
+
+	result, err := rawEventHandler(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, ok := result.(events.APIGatewayV2HTTPResponse)
+	if !ok {
+		t.Fatalf("expected events.APIGatewayV2HTTPResponse, got %T", result)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}